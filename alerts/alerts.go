@@ -0,0 +1,22 @@
+// Package alerts routes threshold breaches detected by plugins to one or
+// more external notification channels.
+package alerts
+
+import "context"
+
+// Event describes a single threshold crossing for a metric on a host.
+type Event struct {
+	Host      string
+	Metric    string
+	Value     float64
+	Threshold float64
+	Message   string
+}
+
+// Notifier delivers an Event to an external channel. Fire is called when a
+// Policy decides a metric has crossed into alert state; Resolve is called
+// once it has settled back below threshold.
+type Notifier interface {
+	Fire(ctx context.Context, e Event) error
+	Resolve(ctx context.Context, e Event) error
+}
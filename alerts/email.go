@@ -0,0 +1,44 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPConfig configures an SMTPNotifier.
+type SMTPConfig struct {
+	Addr string // host:port of the mail relay
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+// SMTPNotifier emails Fire/Resolve events through an SMTP relay.
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotifier builds a Notifier that sends mail through cfg.
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg}
+}
+
+func (n *SMTPNotifier) Fire(ctx context.Context, e Event) error {
+	return n.send(fmt.Sprintf("[ALERT] %s on %s", e.Metric, e.Host), e.Message)
+}
+
+func (n *SMTPNotifier) Resolve(ctx context.Context, e Event) error {
+	return n.send(fmt.Sprintf("[RESOLVED] %s on %s", e.Metric, e.Host), e.Message)
+}
+
+func (n *SMTPNotifier) send(subject, body string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+		n.cfg.From, strings.Join(n.cfg.To, ","), subject, body)
+
+	if err := smtp.SendMail(n.cfg.Addr, n.cfg.Auth, n.cfg.From, n.cfg.To, []byte(msg)); err != nil {
+		return fmt.Errorf("alerts: smtp: send mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyConfig configures a PagerDutyNotifier.
+type PagerDutyConfig struct {
+	RoutingKey string
+	Addr       string // defaults to the public Events API v2 endpoint
+}
+
+// PagerDutyNotifier fires and resolves incidents via the PagerDuty Events
+// API v2, deduping on host+metric so repeated Fire calls update the same
+// incident rather than opening a new one each time.
+type PagerDutyNotifier struct {
+	cfg    PagerDutyConfig
+	client *http.Client
+}
+
+// NewPagerDutyNotifier builds a Notifier that sends events to PagerDuty
+// using cfg.
+func NewPagerDutyNotifier(cfg PagerDutyConfig) *PagerDutyNotifier {
+	if cfg.Addr == "" {
+		cfg.Addr = pagerDutyEventsURL
+	}
+	return &PagerDutyNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (n *PagerDutyNotifier) Fire(ctx context.Context, e Event) error {
+	return n.send(ctx, e, "trigger")
+}
+
+func (n *PagerDutyNotifier) Resolve(ctx context.Context, e Event) error {
+	return n.send(ctx, e, "resolve")
+}
+
+type pagerDutyPayload struct {
+	Summary  string `json:"summary"`
+	Source   string `json:"source"`
+	Severity string `json:"severity"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+func (n *PagerDutyNotifier) send(ctx context.Context, e Event, action string) error {
+	body, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  n.cfg.RoutingKey,
+		EventAction: action,
+		DedupKey:    e.Host + "/" + e.Metric,
+		Payload: pagerDutyPayload{
+			Summary:  e.Message,
+			Source:   e.Host,
+			Severity: "critical",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("alerts: pagerduty: marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.cfg.Addr, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alerts: pagerduty: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alerts: pagerduty: send event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alerts: pagerduty: send event: returned status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package alerts
+
+// Policy implements the "N consecutive samples above threshold fires, M
+// consecutive samples back below resolves" hysteresis that plugins use to
+// avoid flapping alerts on a noisy metric.
+type Policy struct {
+	AlertSamples   int
+	ResolveSamples int
+
+	alertCount   int
+	resolveCount int
+	triggered    bool
+}
+
+// NewPolicy builds a Policy requiring alertSamples consecutive breaches to
+// fire and resolveSamples consecutive samples back under threshold to
+// resolve.
+func NewPolicy(alertSamples, resolveSamples int) *Policy {
+	return &Policy{AlertSamples: alertSamples, ResolveSamples: resolveSamples}
+}
+
+// Evaluate records one observation and reports whether this sample should
+// cause a Fire or a Resolve. Once alertCount reaches AlertSamples it keeps
+// reporting shouldFire on every subsequent breaching sample, so a caller can
+// use it to re-notify at its own interval for as long as the metric stays
+// over threshold.
+func (p *Policy) Evaluate(above bool) (shouldFire, shouldResolve bool) {
+	if above {
+		if p.alertCount < p.AlertSamples {
+			p.alertCount++
+		}
+		p.resolveCount = 0
+	} else {
+		if p.resolveCount < p.ResolveSamples {
+			p.resolveCount++
+		}
+		p.alertCount = 0
+	}
+
+	if p.alertCount == p.AlertSamples {
+		p.triggered = true
+		return true, false
+	}
+	if p.triggered && p.resolveCount == p.ResolveSamples {
+		p.triggered = false
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,74 @@
+package alerts
+
+import "testing"
+
+func TestPolicyFiresAfterConsecutiveBreaches(t *testing.T) {
+	p := NewPolicy(3, 3)
+
+	for i := 0; i < 2; i++ {
+		if fire, _ := p.Evaluate(true); fire {
+			t.Fatalf("Evaluate fired after only %d breaching samples", i+1)
+		}
+	}
+
+	fire, resolve := p.Evaluate(true)
+	if !fire || resolve {
+		t.Fatalf("Evaluate(true) on 3rd breach = (%v, %v), want (true, false)", fire, resolve)
+	}
+}
+
+func TestPolicyKeepsFiringWhileBreaching(t *testing.T) {
+	p := NewPolicy(3, 3)
+	for i := 0; i < 3; i++ {
+		p.Evaluate(true)
+	}
+
+	fire, resolve := p.Evaluate(true)
+	if !fire || resolve {
+		t.Fatalf("Evaluate(true) after already firing = (%v, %v), want (true, false)", fire, resolve)
+	}
+}
+
+func TestPolicyResolvesAfterConsecutiveRecoveries(t *testing.T) {
+	p := NewPolicy(3, 3)
+	for i := 0; i < 3; i++ {
+		p.Evaluate(true)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, resolve := p.Evaluate(false); resolve {
+			t.Fatalf("Evaluate resolved after only %d recovering samples", i+1)
+		}
+	}
+
+	fire, resolve := p.Evaluate(false)
+	if fire || !resolve {
+		t.Fatalf("Evaluate(false) on 3rd recovery = (%v, %v), want (false, true)", fire, resolve)
+	}
+}
+
+func TestPolicyDoesNotResolveWithoutHavingFired(t *testing.T) {
+	p := NewPolicy(3, 3)
+	for i := 0; i < 5; i++ {
+		if _, resolve := p.Evaluate(false); resolve {
+			t.Fatalf("Evaluate resolved without ever firing")
+		}
+	}
+}
+
+func TestPolicyBreachResetsRecoveryCount(t *testing.T) {
+	p := NewPolicy(3, 3)
+	for i := 0; i < 3; i++ {
+		p.Evaluate(true)
+	}
+
+	p.Evaluate(false)
+	p.Evaluate(false)
+	p.Evaluate(true) // breaches again before the 3rd recovery sample
+
+	for i := 0; i < 2; i++ {
+		if _, resolve := p.Evaluate(false); resolve {
+			t.Fatalf("Evaluate resolved after only %d recovering samples since the reset breach", i+1)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SlackNotifier posts Fire/Resolve events to a Slack incoming webhook. If
+// URL is empty, messages are printed to stdout instead, which is handy for
+// local runs that have no webhook configured.
+type SlackNotifier struct {
+	URL    string
+	client *http.Client
+}
+
+// NewSlackNotifier builds a Notifier that posts to the given Slack webhook
+// URL.
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{
+		URL:    url,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (n *SlackNotifier) Fire(ctx context.Context, e Event) error {
+	return n.post(e.Host, fmt.Sprintf("[ALERT]: %s", e.Message))
+}
+
+func (n *SlackNotifier) Resolve(ctx context.Context, e Event) error {
+	return n.post(e.Host, fmt.Sprintf("[RESOLVED]: %s", e.Message))
+}
+
+func (n *SlackNotifier) post(hostname, msg string) error {
+	if len(n.URL) == 0 {
+		fmt.Printf("%s\n", msg)
+		return nil
+	}
+
+	type message struct {
+		Text string `json:"text"`
+	}
+	m := message{Text: fmt.Sprintf("report from host %s\n%s", hostname, msg)}
+
+	body, err := json.Marshal(&m)
+	if err != nil {
+		return fmt.Errorf("alerts: slack: marshal message: %w", err)
+	}
+
+	v := url.Values{}
+	v.Set("payload", string(body))
+	resp, err := n.client.PostForm(n.URL, v)
+	if err != nil {
+		return fmt.Errorf("alerts: slack: post notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,85 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// WebhookConfig configures a WebhookNotifier.
+type WebhookConfig struct {
+	URL     string
+	Headers map[string]string
+}
+
+// WebhookNotifier POSTs a generic JSON payload to an arbitrary URL, for
+// operators whose alerting backend isn't one of the named integrations.
+type WebhookNotifier struct {
+	cfg    WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookNotifier builds a Notifier that posts to the URL in cfg.
+func NewWebhookNotifier(cfg WebhookConfig) *WebhookNotifier {
+	return &WebhookNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+type webhookPayload struct {
+	State     string  `json:"state"` // "firing" or "resolved"
+	Host      string  `json:"host"`
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Message   string  `json:"message"`
+}
+
+func (n *WebhookNotifier) Fire(ctx context.Context, e Event) error {
+	return n.send(ctx, e, "firing")
+}
+
+func (n *WebhookNotifier) Resolve(ctx context.Context, e Event) error {
+	return n.send(ctx, e, "resolved")
+}
+
+func (n *WebhookNotifier) send(ctx context.Context, e Event, state string) error {
+	body, err := json.Marshal(webhookPayload{
+		State:     state,
+		Host:      e.Host,
+		Metric:    e.Metric,
+		Value:     e.Value,
+		Threshold: e.Threshold,
+		Message:   e.Message,
+	})
+	if err != nil {
+		return fmt.Errorf("alerts: webhook: marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alerts: webhook: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alerts: webhook: send event: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alerts: webhook: send event: returned status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// EWMA is an exponentially-weighted moving average of a rate, computed the
+// same way the 1/5/15 minute unix load averages are: samples are folded in
+// on a ticker with a fixed period rather than whenever Update happens to be
+// called, so the decay constant (and therefore the "1 minute" in the name)
+// means what it says regardless of how bursty the input is.
+type EWMA struct {
+	uncounted   uint64 // atomic, accessed outside of any lock
+	alpha       float64
+	interval    float64 // Tick period in seconds
+	initialized bool
+	rate        float64
+}
+
+func newEWMA(interval time.Duration, minutes float64) *EWMA {
+	return &EWMA{
+		alpha:    1 - math.Exp(-interval.Seconds()/60/minutes),
+		interval: interval.Seconds(),
+	}
+}
+
+// NewEWMA1 builds an EWMA with the 1-minute decay constant, ticked every interval.
+func NewEWMA1(interval time.Duration) *EWMA {
+	return newEWMA(interval, 1)
+}
+
+// NewEWMA5 builds an EWMA with the 5-minute decay constant, ticked every interval.
+func NewEWMA5(interval time.Duration) *EWMA {
+	return newEWMA(interval, 5)
+}
+
+// NewEWMA15 builds an EWMA with the 15-minute decay constant, ticked every interval.
+func NewEWMA15(interval time.Duration) *EWMA {
+	return newEWMA(interval, 15)
+}
+
+// Update adds n to the count that will be folded into the rate on the next Tick.
+func (e *EWMA) Update(n uint64) {
+	atomic.AddUint64(&e.uncounted, n)
+}
+
+// Tick folds the count accumulated since the last Tick into the rate. It
+// must be called from a single ticker at the fixed interval this EWMA was
+// constructed with, shared by every EWMA that should stay in sync.
+func (e *EWMA) Tick() {
+	uncounted := atomic.SwapUint64(&e.uncounted, 0)
+	instantRate := float64(uncounted) / e.interval
+
+	if e.initialized {
+		e.rate += e.alpha * (instantRate - e.rate)
+	} else {
+		e.rate = instantRate
+		e.initialized = true
+	}
+}
+
+// Rate returns the current per-second rate.
+func (e *EWMA) Rate() float64 {
+	return e.rate
+}
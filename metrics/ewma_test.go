@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEWMARateZeroBeforeFirstTick(t *testing.T) {
+	e := NewEWMA1(5 * time.Second)
+	if rate := e.Rate(); rate != 0 {
+		t.Fatalf("Rate() before any Tick = %v, want 0", rate)
+	}
+}
+
+func TestEWMATickSeedsOnFirstSample(t *testing.T) {
+	e := NewEWMA1(5 * time.Second)
+	e.Update(50) // 50 events over a 5s interval -> instant rate of 10/s
+	e.Tick()
+
+	if rate := e.Rate(); rate != 10 {
+		t.Fatalf("Rate() after first Tick = %v, want 10", rate)
+	}
+}
+
+func TestEWMATickDecaysTowardInstantRate(t *testing.T) {
+	e := NewEWMA1(5 * time.Second)
+
+	e.Update(50)
+	e.Tick() // rate == 10
+
+	e.Update(100)
+	e.Tick() // instant rate 20/s, should move partway from 10 toward 20
+
+	rate := e.Rate()
+	if rate <= 10 || rate >= 20 {
+		t.Fatalf("Rate() after second Tick = %v, want strictly between 10 and 20", rate)
+	}
+}
+
+func TestEWMATickWithNoUpdatesDecaysTowardZero(t *testing.T) {
+	e := NewEWMA1(5 * time.Second)
+
+	e.Update(50)
+	e.Tick()
+	first := e.Rate()
+
+	e.Tick() // no Update in between -> instant rate 0
+	second := e.Rate()
+
+	if second >= first {
+		t.Fatalf("Rate() after idle Tick = %v, want less than previous %v", second, first)
+	}
+	if second < 0 {
+		t.Fatalf("Rate() = %v, want non-negative", second)
+	}
+}
+
+func TestEWMAConvergesToSteadyRate(t *testing.T) {
+	e := NewEWMA1(5 * time.Second)
+
+	for i := 0; i < 1000; i++ {
+		e.Update(50) // steady 10/s
+		e.Tick()
+	}
+
+	if rate := e.Rate(); math.Abs(rate-10) > 0.001 {
+		t.Fatalf("Rate() after convergence = %v, want ~10", rate)
+	}
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/customerio/monitor/plugins"
+)
+
+// GraphiteConfig configures a GraphiteReporter.
+type GraphiteConfig struct {
+	Addr   string // host:port of the Graphite carbon plaintext listener
+	Prefix string
+}
+
+// GraphiteReporter batches samples and writes them to a Graphite carbon
+// listener using the plaintext protocol on a fixed interval.
+type GraphiteReporter struct {
+	cfg GraphiteConfig
+
+	mux   sync.Mutex
+	lines []string
+}
+
+// NewGraphiteReporter builds a Reporter that writes to the Graphite carbon
+// listener described by cfg.
+func NewGraphiteReporter(cfg GraphiteConfig) *GraphiteReporter {
+	return &GraphiteReporter{cfg: cfg}
+}
+
+func (r *GraphiteReporter) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.flush()
+			case <-ctx.Done():
+				r.flush()
+				return
+			}
+		}
+	}()
+}
+
+func (r *GraphiteReporter) ReportGauge(name string, labels map[string]string, value float64) {
+	r.addLine(name, labels, fmt.Sprintf("%v", value))
+}
+
+func (r *GraphiteReporter) ReportCounter(name string, labels map[string]string, value int64) {
+	r.addLine(name, labels, fmt.Sprintf("%d", value))
+}
+
+func (r *GraphiteReporter) ReportHistogram(name string, labels map[string]string, value float64) {
+	r.addLine(name, labels, fmt.Sprintf("%v", value))
+}
+
+// addLine formats name plus its labels using Graphite's tagged-series
+// syntax (name;k=v;k2=v2), which carbon has accepted since 1.1.
+func (r *GraphiteReporter) addLine(name string, labels map[string]string, value string) {
+	metric := name
+	if r.cfg.Prefix != "" {
+		metric = r.cfg.Prefix + "." + name
+	}
+	for k, v := range labels {
+		metric += fmt.Sprintf(";%s=%s", k, v)
+	}
+
+	line := fmt.Sprintf("%s %s %d", metric, value, time.Now().Unix())
+
+	r.mux.Lock()
+	r.lines = append(r.lines, line)
+	r.mux.Unlock()
+}
+
+func (r *GraphiteReporter) flush() {
+	r.mux.Lock()
+	lines := r.lines
+	r.lines = nil
+	r.mux.Unlock()
+
+	if len(lines) == 0 {
+		return
+	}
+
+	conn, err := net.DialTimeout("tcp", r.cfg.Addr, time.Second*10)
+	if err != nil {
+		plugins.Logger.Printf("metrics: graphite: dial: %v\n", err)
+		return
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprint(conn, strings.Join(lines, "\n")+"\n"); err != nil {
+		plugins.Logger.Printf("metrics: graphite: write: %v\n", err)
+	}
+}
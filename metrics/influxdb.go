@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/customerio/monitor/plugins"
+)
+
+// InfluxDBConfig configures an InfluxDBReporter.
+type InfluxDBConfig struct {
+	Addr        string // e.g. http://localhost:8086
+	Database    string
+	Measurement string
+	Tags        map[string]string
+	Username    string
+	Password    string
+}
+
+// InfluxDBReporter batches samples as line-protocol points and writes them
+// to an InfluxDB HTTP endpoint on a fixed interval.
+type InfluxDBReporter struct {
+	cfg    InfluxDBConfig
+	client *http.Client
+
+	mux    sync.Mutex
+	points []string
+}
+
+// NewInfluxDBReporter builds a Reporter that writes to the InfluxDB instance
+// described by cfg.
+func NewInfluxDBReporter(cfg InfluxDBConfig) *InfluxDBReporter {
+	return &InfluxDBReporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (r *InfluxDBReporter) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.flush()
+			case <-ctx.Done():
+				r.flush()
+				return
+			}
+		}
+	}()
+}
+
+func (r *InfluxDBReporter) ReportGauge(name string, labels map[string]string, value float64) {
+	r.addPoint(name, labels, fmt.Sprintf("value=%v", value))
+}
+
+func (r *InfluxDBReporter) ReportCounter(name string, labels map[string]string, value int64) {
+	r.addPoint(name, labels, fmt.Sprintf("value=%vi", value))
+}
+
+func (r *InfluxDBReporter) ReportHistogram(name string, labels map[string]string, value float64) {
+	r.addPoint(name, labels, fmt.Sprintf("value=%v", value))
+}
+
+func (r *InfluxDBReporter) addPoint(name string, labels map[string]string, fields string) {
+	tags := ""
+	for k, v := range r.cfg.Tags {
+		tags += fmt.Sprintf(",%s=%s", k, v)
+	}
+	for k, v := range labels {
+		tags += fmt.Sprintf(",%s=%s", k, v)
+	}
+
+	point := fmt.Sprintf("%s,metric=%s%s %s %d", r.cfg.Measurement, name, tags, fields, time.Now().UnixNano())
+
+	r.mux.Lock()
+	r.points = append(r.points, point)
+	r.mux.Unlock()
+}
+
+func (r *InfluxDBReporter) flush() {
+	r.mux.Lock()
+	points := r.points
+	r.points = nil
+	r.mux.Unlock()
+
+	if len(points) == 0 {
+		return
+	}
+
+	u := fmt.Sprintf("%s/write?%s", strings.TrimRight(r.cfg.Addr, "/"), url.Values{"db": {r.cfg.Database}}.Encode())
+
+	req, err := http.NewRequest("POST", u, bytes.NewBufferString(strings.Join(points, "\n")))
+	if err != nil {
+		plugins.Logger.Printf("metrics: influxdb: build request: %v\n", err)
+		return
+	}
+	if r.cfg.Username != "" {
+		req.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		plugins.Logger.Printf("metrics: influxdb: write: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		plugins.Logger.Printf("metrics: influxdb: write returned status %s\n", resp.Status)
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Reporter publishes the samples collected by a plugin to a backend outside
+// of this process, in addition to (not instead of) the existing Batch sink.
+// Start is called once to let an implementation set up any connections or
+// background flushing it needs; the per-metric-type Report* methods are
+// called synchronously as samples are produced. labels carries the
+// registry's base labels (host, datacenter, ...) merged with any labels on
+// the sample itself, so every reporter sees the same dimensions regardless
+// of whether the originating gauge was tagged.
+type Reporter interface {
+	Start(ctx context.Context, interval time.Duration)
+	ReportGauge(name string, labels map[string]string, value float64)
+	ReportCounter(name string, labels map[string]string, value int64)
+	ReportHistogram(name string, labels map[string]string, value float64)
+}
+
+// Registry fans a sample out to every Reporter registered with it. Plugins
+// hold a *Registry instead of talking to individual Reporters so operators
+// can point a monitor at any combination of backends from config alone.
+type Registry struct {
+	reporters  []Reporter
+	baseLabels map[string]string
+}
+
+// RegistryOption configures a Registry at construction time.
+type RegistryOption func(*Registry)
+
+// WithBaseLabels attaches labels (typically host and datacenter) to every
+// sample forwarded by the registry, regardless of whether the gauge that
+// produced it is tagged.
+func WithBaseLabels(labels map[string]string) RegistryOption {
+	return func(r *Registry) {
+		r.baseLabels = labels
+	}
+}
+
+// NewRegistry builds a Registry over the given reporters. A nil or empty
+// list is valid and makes the registry a no-op, so plugins can always hold
+// one without checking whether reporting was configured.
+func NewRegistry(reporters []Reporter, opts ...RegistryOption) *Registry {
+	r := &Registry{reporters: reporters}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start calls Start on every registered Reporter.
+func (r *Registry) Start(ctx context.Context, interval time.Duration) {
+	for _, rep := range r.reporters {
+		rep.Start(ctx, interval)
+	}
+}
+
+// mergeLabels combines the registry's base labels (host, datacenter, ...)
+// with labels specific to this sample (e.g. iface), without mutating either
+// map. extra wins on key collisions.
+func (r *Registry) mergeLabels(extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return r.baseLabels
+	}
+
+	labels := make(map[string]string, len(r.baseLabels)+len(extra))
+	for k, v := range r.baseLabels {
+		labels[k] = v
+	}
+	for k, v := range extra {
+		labels[k] = v
+	}
+	return labels
+}
+
+func (r *Registry) ReportGauge(name string, labels map[string]string, value float64) {
+	merged := r.mergeLabels(labels)
+	for _, rep := range r.reporters {
+		rep.ReportGauge(name, merged, value)
+	}
+}
+
+func (r *Registry) ReportCounter(name string, labels map[string]string, value int64) {
+	merged := r.mergeLabels(labels)
+	for _, rep := range r.reporters {
+		rep.ReportCounter(name, merged, value)
+	}
+}
+
+func (r *Registry) ReportHistogram(name string, labels map[string]string, value float64) {
+	merged := r.mergeLabels(labels)
+	for _, rep := range r.reporters {
+		rep.ReportHistogram(name, merged, value)
+	}
+}
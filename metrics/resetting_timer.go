@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ResettingTimerSnapshot holds the summary statistics computed over every
+// duration recorded since the previous Snapshot.
+type ResettingTimerSnapshot struct {
+	Count       int
+	Min         time.Duration
+	Max         time.Duration
+	Mean        time.Duration
+	Percentiles map[float64]time.Duration
+}
+
+// ResettingTimer records individual latency samples and, on each Snapshot,
+// sorts them, computes percentiles plus min/max/mean/count, and clears its
+// buffer so the next window starts empty. That makes it cheap to sprinkle
+// on hot paths: unlike a regular histogram it never accumulates samples
+// across windows.
+type ResettingTimer struct {
+	mux         sync.Mutex
+	values      []time.Duration
+	percentiles []float64
+}
+
+// NewResettingTimer builds a ResettingTimer reporting the given percentiles
+// (e.g. 0.5, 0.95, 0.99) on each Snapshot.
+func NewResettingTimer(percentiles ...float64) *ResettingTimer {
+	return &ResettingTimer{percentiles: percentiles}
+}
+
+// Update records a single duration sample.
+func (t *ResettingTimer) Update(d time.Duration) {
+	t.mux.Lock()
+	t.values = append(t.values, d)
+	t.mux.Unlock()
+}
+
+// Snapshot computes the summary statistics over every sample recorded since
+// the last Snapshot, then clears the buffer.
+func (t *ResettingTimer) Snapshot() ResettingTimerSnapshot {
+	t.mux.Lock()
+	values := t.values
+	t.values = nil
+	t.mux.Unlock()
+
+	snapshot := ResettingTimerSnapshot{
+		Count:       len(values),
+		Percentiles: make(map[float64]time.Duration, len(t.percentiles)),
+	}
+	if len(values) == 0 {
+		return snapshot
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	snapshot.Min = values[0]
+	snapshot.Max = values[len(values)-1]
+
+	var sum time.Duration
+	for _, v := range values {
+		sum += v
+	}
+	snapshot.Mean = sum / time.Duration(len(values))
+
+	for _, p := range t.percentiles {
+		snapshot.Percentiles[p] = percentile(values, p)
+	}
+
+	return snapshot
+}
+
+// Flush is an alias for Snapshot, for callers that think of this as
+// draining a buffer rather than reading a point-in-time view.
+func (t *ResettingTimer) Flush() ResettingTimerSnapshot {
+	return t.Snapshot()
+}
+
+// percentile assumes sorted is already sorted ascending.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p * float64(len(sorted)))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
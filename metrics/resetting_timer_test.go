@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResettingTimerAggregatesAcrossUpdates(t *testing.T) {
+	timer := NewResettingTimer(0.5, 0.99)
+
+	for _, ms := range []int{10, 20, 30, 40, 100} {
+		timer.Update(time.Duration(ms) * time.Millisecond)
+	}
+
+	snapshot := timer.Snapshot()
+
+	if snapshot.Count != 5 {
+		t.Fatalf("Count = %d, want 5", snapshot.Count)
+	}
+	if snapshot.Min != 10*time.Millisecond {
+		t.Fatalf("Min = %v, want 10ms", snapshot.Min)
+	}
+	if snapshot.Max != 100*time.Millisecond {
+		t.Fatalf("Max = %v, want 100ms", snapshot.Max)
+	}
+}
+
+func TestResettingTimerSnapshotClearsBuffer(t *testing.T) {
+	timer := NewResettingTimer(0.5)
+
+	timer.Update(10 * time.Millisecond)
+	timer.Snapshot()
+
+	// A second Snapshot with no intervening Update should see no samples;
+	// if the buffer hadn't been cleared, Count would still be 1.
+	snapshot := timer.Snapshot()
+	if snapshot.Count != 0 {
+		t.Fatalf("Count after empty window = %d, want 0", snapshot.Count)
+	}
+}
+
+func TestResettingTimerSingleSampleHasFlatPercentiles(t *testing.T) {
+	timer := NewResettingTimer(0.5, 0.95, 0.99)
+	timer.Update(42 * time.Millisecond)
+
+	snapshot := timer.Snapshot()
+	for _, p := range []float64{0.5, 0.95, 0.99} {
+		if snapshot.Percentiles[p] != 42*time.Millisecond {
+			t.Fatalf("Percentiles[%v] = %v, want 42ms", p, snapshot.Percentiles[p])
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package metrics
+
+// TaggedGauge is a Gauge whose identity includes a set of labels (e.g. host,
+// datacenter) in addition to its name. This lets samples be aggregated
+// across a fleet without cramming identity into the metric name itself,
+// which is how modern TSDBs expect dimensions to be expressed.
+type TaggedGauge struct {
+	Updater
+	name   string
+	labels map[string]string
+}
+
+// NewTaggedGauge wraps a Gauge named name with the given labels.
+func NewTaggedGauge(name string, labels map[string]string) *TaggedGauge {
+	return &TaggedGauge{
+		Updater: NewGauge(name),
+		name:    name,
+		labels:  labels,
+	}
+}
+
+// Name returns the untagged metric name.
+func (g *TaggedGauge) Name() string {
+	return g.name
+}
+
+// Labels returns the labels this gauge's samples should carry.
+func (g *TaggedGauge) Labels() map[string]string {
+	return g.labels
+}
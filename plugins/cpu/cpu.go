@@ -1,15 +1,12 @@
 package cpu
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"net/http"
-	"net/url"
 	"sync"
 	"time"
 
+	"github.com/customerio/monitor/alerts"
 	"github.com/customerio/monitor/metrics"
 )
 
@@ -20,70 +17,81 @@ const (
 	niceGauge
 )
 
-// Hold cpu sampled data and calculate moving averages
-type sample struct {
-	alpha        float64
-	expMovingAvg float64
-	values       []float64
-	position     int
-	filled       bool
-}
+var categoryNames = [...]string{userGauge: "user", systemGauge: "system", idleGauge: "idle", niceGauge: "nice"}
 
-func newSample(alpha float64, ringSize float64) *sample {
-	return &sample{
-		alpha:  alpha,
-		values: make([]float64, int64(ringSize)),
-	}
+// The three conventional load-average windows, expressed as indexes into an
+// ewmaSet and the updaters/reporter names derived from it.
+const (
+	window1 = iota
+	window5
+	window15
+	windowCount
+)
+
+var windowNames = [...]string{window1: "1m", window5: "5m", window15: "15m"}
+
+// ewmaTickInterval is the fixed period at which every EWMA in this plugin is
+// ticked, independent of SampleRate. Using unix's own 5s load-average
+// sampling period means the 1/5/15 "minute" decay constants mean what they
+// say regardless of how often CPU.collect runs.
+const ewmaTickInterval = 5 * time.Second
+
+// collectPercentiles are the latency percentiles tracked for this plugin's
+// own monitor.collect.cpu.* timing gauges.
+var collectPercentiles = []float64{0.5, 0.95, 0.99}
+
+// ewmaSet holds the 1/5/15 minute moving averages for a single counter
+// (e.g. user jiffies/sec), fed by the same underlying samples.
+type ewmaSet struct {
+	m1  *metrics.EWMA
+	m5  *metrics.EWMA
+	m15 *metrics.EWMA
 }
 
-func (s *sample) add(v float64) {
-	if s.position == 0 && !s.filled {
-		s.expMovingAvg = v
-	} else {
-		s.expMovingAvg = v*s.alpha + (s.expMovingAvg * (1 - s.alpha))
+func newEWMASet(tick time.Duration) *ewmaSet {
+	return &ewmaSet{
+		m1:  metrics.NewEWMA1(tick),
+		m5:  metrics.NewEWMA5(tick),
+		m15: metrics.NewEWMA15(tick),
 	}
+}
 
-	s.values[s.position] = v
-	s.position = (s.position + 1) % len(s.values)
-	if s.position == 0 {
-		s.filled = true
-	}
+func (s *ewmaSet) update(n uint64) {
+	s.m1.Update(n)
+	s.m5.Update(n)
+	s.m15.Update(n)
 }
 
-func (s *sample) movingAvg() float64 {
-	if len(s.values) == 0 {
-		return 0
-	}
-	var (
-		subSet     = (len(s.values) / 2) + (len(s.values) % 2)
-		toRange    = len(s.values) / 2
-		avgs       []float64
-		sum, count float64
-	)
-
-	// If have not filled the ring buffer yet only  get average for inserted
-	// values, otherwise 0s will throw off the average.
-	if !s.filled {
-		subSet = (s.position / 2) + (s.position % 2)
-		toRange = s.position / 2
-	}
+func (s *ewmaSet) tick() {
+	s.m1.Tick()
+	s.m5.Tick()
+	s.m15.Tick()
+}
 
-	for i := 0; i <= toRange; i++ {
-		for _, v := range s.values[i : i+subSet] {
-			sum += v
-			count += 1
-		}
-		avgs = append(avgs, (sum / count))
-		sum = 0
-		count = 0
+func (s *ewmaSet) rate(window int) float64 {
+	switch window {
+	case window5:
+		return s.m5.Rate()
+	case window15:
+		return s.m15.Rate()
+	default:
+		return s.m1.Rate()
 	}
+}
 
-	for _, v := range avgs {
-		sum += v
-		count += 1
+func windowFromDuration(d time.Duration) int {
+	switch d {
+	case 5 * time.Minute:
+		return window5
+	case 15 * time.Minute:
+		return window15
+	default:
+		return window1
 	}
+}
 
-	return sum / count
+func updaterIndex(gauge, window int) int {
+	return gauge*windowCount + window
 }
 
 type CPU struct {
@@ -93,77 +101,133 @@ type CPU struct {
 	currentTotal  int
 	previousTotal int
 	lastUpdate    time.Time
-	averages      []*sample
+	ewmas         []*ewmaSet // indexed by userGauge, systemGauge, idleGauge
+	names         []string   // indexed by updaterIndex(gauge, window)
 	updaters      []metrics.Updater
-	slackURL      string
+	registry      *metrics.Registry
+	notifiers     []alerts.Notifier
+	collectTimer  *metrics.ResettingTimer
+	timerUpdaters map[float64]metrics.Updater
 }
 
 type Config struct {
 	Threshold         float64
 	SampleRate        time.Duration
 	ReportingInterval time.Duration
-	SlackURL          string
-	SlackInterval     time.Duration
 	Hostname          string
+	Datacenter        string
+
+	// ThresholdWindow picks which moving average the alert threshold is
+	// checked against: time.Minute, 5*time.Minute, or 15*time.Minute.
+	// Defaults to the 1 minute average.
+	ThresholdWindow time.Duration
+
+	// AlertInterval is the minimum time between two notifications, Fire or
+	// Resolve alike: while cpu.user stays over threshold, Fire is re-sent at
+	// most once per AlertInterval, and a Resolve that comes within
+	// AlertInterval of the previous notification is not sent.
+	AlertInterval time.Duration
+
+	// Notifiers receive a Fire when cpu.user crosses Threshold for 3
+	// consecutive samples, and a Resolve once it's back under threshold for
+	// 3 consecutive samples.
+	Notifiers []alerts.Notifier
+
+	// Labels are attached to every gauge emitted by this plugin in addition
+	// to host and datacenter, e.g. for a role or environment tag.
+	Labels map[string]string
+
+	// Reporters receive every sample collected here in addition to the
+	// existing Batch sink, so operators can point this monitor at InfluxDB,
+	// Graphite, or any other metrics.Reporter without code changes.
+	Reporters []metrics.Reporter
+}
+
+func baseLabels(hostname, datacenter string, extra map[string]string) map[string]string {
+	labels := map[string]string{"host": hostname, "datacenter": datacenter}
+	for k, v := range extra {
+		labels[k] = v
+	}
+	return labels
 }
 
 func New(cfg *Config) *CPU {
-	var (
-		// Determine the alpha factor for exponential moving average as described here
-		// https://en.wikipedia.org/wiki/Moving_average#Application_to_measuring_computer_performance
-		alpha        = cfg.SampleRate.Seconds() / cfg.ReportingInterval.Seconds()
-		ringSize     = cfg.ReportingInterval.Seconds() / cfg.SampleRate.Seconds()
-		triggered    bool
-		lastUpdate   time.Time
-		alertCount   int
-		resolveCount int
-	)
+	var lastUpdate time.Time
+
+	labels := baseLabels(cfg.Hostname, cfg.Datacenter, cfg.Labels)
+	thresholdWindow := windowFromDuration(cfg.ThresholdWindow)
+	policy := alerts.NewPolicy(3, 3)
 
 	c := &CPU{
-		averages: []*sample{
-			userGauge:   newSample(alpha, ringSize),
-			systemGauge: newSample(alpha, 1), // Moving average not used for alerts
-			idleGauge:   newSample(alpha, 1), // Moving average not used for alerts
-		},
-		updaters: []metrics.Updater{
-			userGauge:   metrics.NewGauge("cpu.user"),
-			systemGauge: metrics.NewGauge("cpu.system"),
-			idleGauge:   metrics.NewGauge("cpu.idle"),
+		ewmas: []*ewmaSet{
+			userGauge:   newEWMASet(ewmaTickInterval),
+			systemGauge: newEWMASet(ewmaTickInterval),
+			idleGauge:   newEWMASet(ewmaTickInterval),
 		},
-		slackURL: cfg.SlackURL,
+		names:         make([]string, 3*windowCount),
+		updaters:      make([]metrics.Updater, 3*windowCount),
+		registry:      metrics.NewRegistry(cfg.Reporters, metrics.WithBaseLabels(labels)),
+		notifiers:     cfg.Notifiers,
+		collectTimer:  metrics.NewResettingTimer(collectPercentiles...),
+		timerUpdaters: make(map[float64]metrics.Updater, len(collectPercentiles)),
+	}
+
+	percentileNames := map[float64]string{0.5: "p50_ms", 0.95: "p95_ms", 0.99: "p99_ms"}
+	for _, p := range collectPercentiles {
+		c.timerUpdaters[p] = metrics.NewTaggedGauge("monitor.collect.cpu."+percentileNames[p], labels)
+	}
+
+	for _, g := range []int{userGauge, systemGauge, idleGauge} {
+		for w := 0; w < windowCount; w++ {
+			name := fmt.Sprintf("cpu.%s.%s", categoryNames[g], windowNames[w])
+			c.names[updaterIndex(g, w)] = name
+			c.updaters[updaterIndex(g, w)] = metrics.NewTaggedGauge(name, labels)
+		}
 	}
 
+	c.registry.Start(context.Background(), cfg.ReportingInterval)
+
 	go func() {
-		c.collect()
+		for range time.Tick(ewmaTickInterval) {
+			// Tick mutates rate/initialized on every EWMA in c.ewmas, which
+			// percent() also reads from the SampleRate loop and Collect.
+			// Both sides must serialize through c.mux since EWMA itself
+			// isn't safe for concurrent Tick/Rate.
+			c.mux.Lock()
+			for _, g := range []int{userGauge, systemGauge, idleGauge} {
+				c.ewmas[g].tick()
+			}
+			c.mux.Unlock()
+		}
+	}()
+
+	go func() {
+		c.timeCollect()
 		for range time.Tick(cfg.SampleRate) {
-			c.collect()
+			c.timeCollect()
 			c.mux.Lock()
 			for _, i := range []int{userGauge, systemGauge, idleGauge} {
-				c.averages[i].add(c.rate(i))
+				c.ewmas[i].update(uint64(c.delta(i)))
 			}
-			avg := c.averages[userGauge].movingAvg()
+			avg := c.percent(userGauge, thresholdWindow)
 			c.mux.Unlock()
 
-			if avg >= cfg.Threshold {
-				if alertCount < 3 {
-					alertCount++
-				}
-				resolveCount = 0
-			} else {
-				if resolveCount < 3 {
-					resolveCount++
-				}
-				alertCount = 0
+			event := alerts.Event{
+				Host:      cfg.Hostname,
+				Metric:    "cpu.user",
+				Value:     avg,
+				Threshold: cfg.Threshold,
+				Message:   fmt.Sprintf("cpu.user %s average utilization %f is higher than %f", windowNames[thresholdWindow], avg, cfg.Threshold),
 			}
 
-			if alertCount == 3 && time.Since(lastUpdate) > cfg.SlackInterval {
-				triggered = true
+			fire, resolve := policy.Evaluate(avg >= cfg.Threshold)
+			if fire && time.Since(lastUpdate) > cfg.AlertInterval {
 				lastUpdate = time.Now()
-				c.postSlack(cfg.Hostname, fmt.Sprintf("[ALERT]: cpu.user average utilization %f is higher than %f", avg, cfg.Threshold))
-			} else if triggered && resolveCount == 3 && time.Since(lastUpdate) > cfg.SlackInterval {
-				triggered = false
+				c.notify(event, true)
+			} else if resolve && time.Since(lastUpdate) > cfg.AlertInterval {
 				lastUpdate = time.Now()
-				c.postSlack(cfg.Hostname, "[RESOLVED]: cpu.user average utilization is within threshold")
+				event.Message = "cpu.user average utilization is within threshold"
+				c.notify(event, false)
 			}
 		}
 	}()
@@ -171,46 +235,52 @@ func New(cfg *Config) *CPU {
 	return c
 }
 
-func (c *CPU) postSlack(hostname, msg string) {
-	if len(c.slackURL) == 0 {
-		fmt.Printf("%s\n", msg)
-		return
-	}
-
-	client := &http.Client{Timeout: time.Second * 10}
-
-	type message struct {
-		Text string `json:"text"`
-	}
-	m := message{Text: fmt.Sprintf("report from host %s\n%s", hostname, msg)}
-
-	body, err := json.Marshal(&m)
-	if err != nil {
-		fmt.Printf("cpu: could not marshal message: %v: %s\n", err, msg)
-		return
-	}
-
-	v := url.Values{}
-	v.Set("payload", string(body))
-	resp, err := client.PostForm(c.slackURL, v)
-	if err != nil {
-		fmt.Printf("cpu: post stack notification: %v: %s\n", err, msg)
-		return
+func (c *CPU) notify(e alerts.Event, firing bool) {
+	ctx := context.Background()
+	for _, n := range c.notifiers {
+		var err error
+		if firing {
+			err = n.Fire(ctx, e)
+		} else {
+			err = n.Resolve(ctx, e)
+		}
+		if err != nil {
+			fmt.Printf("cpu: notify: %v\n", err)
+		}
 	}
-
-	defer resp.Body.Close()
-	io.Copy(ioutil.Discard, resp.Body)
 }
 
 func (c *CPU) Collect(b *metrics.Batch) {
 	c.mux.Lock()
-	for _, i := range []int{userGauge, systemGauge, idleGauge} {
-		c.updaters[i].Update(c.averages[i].expMovingAvg)
+	for _, g := range []int{userGauge, systemGauge, idleGauge} {
+		for w := 0; w < windowCount; w++ {
+			p := c.percent(g, w)
+			c.updaters[updaterIndex(g, w)].Update(p)
+			c.registry.ReportGauge(c.names[updaterIndex(g, w)], nil, p)
+		}
 	}
 	c.mux.Unlock()
+
 	for _, u := range c.updaters {
 		u.Fill(b)
 	}
+
+	snapshot := c.collectTimer.Snapshot()
+	for _, p := range collectPercentiles {
+		c.timerUpdaters[p].Update(float64(snapshot.Percentiles[p]) / float64(time.Millisecond))
+	}
+	for _, u := range c.timerUpdaters {
+		u.Fill(b)
+	}
+}
+
+// timeCollect runs collect and records how long /proc/stat parsing took, so
+// operators can see this plugin's own contribution to load via
+// monitor.collect.cpu.p50_ms/p95_ms/p99_ms.
+func (c *CPU) timeCollect() {
+	start := time.Now()
+	c.collect()
+	c.collectTimer.Update(time.Since(start))
 }
 
 func (c *CPU) clear() {
@@ -220,15 +290,24 @@ func (c *CPU) clear() {
 	c.previousTotal = 0
 }
 
-func (c *CPU) rate(name int) float64 {
-	if name >= len(c.current) {
+// delta returns the raw jiffy count accumulated for the given category
+// since the previous sample, for feeding into an ewmaSet.
+func (c *CPU) delta(name int) int {
+	if name >= len(c.current) || name >= len(c.previous) {
 		return 0
 	}
-	delta := c.current[name] - c.previous[name]
-	total := c.currentTotal - c.previousTotal
+	if d := c.current[name] - c.previous[name]; d > 0 {
+		return d
+	}
+	return 0
+}
 
-	if total == 0.0 {
+// percent returns the share of total CPU time category gauge represents
+// over the given window, as a 0-100 percentage.
+func (c *CPU) percent(gauge, window int) float64 {
+	total := c.ewmas[userGauge].rate(window) + c.ewmas[systemGauge].rate(window) + c.ewmas[idleGauge].rate(window)
+	if total == 0 {
 		return 0
 	}
-	return float64(delta) / float64(total) * 100
+	return c.ewmas[gauge].rate(window) / total * 100
 }
@@ -0,0 +1,414 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/customerio/monitor/alerts"
+	"github.com/customerio/monitor/metrics"
+	"github.com/customerio/monitor/plugins"
+)
+
+// counters holds the raw cumulative values read from /proc/net/dev for a
+// single interface.
+type counters struct {
+	rxBytes, rxPackets, rxErrors, rxDrops uint64
+	txBytes, txPackets, txErrors, txDrops uint64
+}
+
+// updaters holds the gauges published for a single interface: one per
+// cumulative field, plus a per-second rate gauge for the byte and packet
+// fields (errors and drops have no rate gauge).
+type updaters struct {
+	rxBytesTotal, txBytesTotal     metrics.Updater
+	rxPacketsTotal, txPacketsTotal metrics.Updater
+	rxErrorsTotal, txErrorsTotal   metrics.Updater
+	rxDropsTotal, txDropsTotal     metrics.Updater
+	rxBytesRate, txBytesRate       metrics.Updater
+	rxPacketsRate, txPacketsRate   metrics.Updater
+}
+
+type Config struct {
+	SampleRate        time.Duration
+	ReportingInterval time.Duration
+	Hostname          string
+	Datacenter        string
+	Labels            map[string]string
+	Reporters         []metrics.Reporter
+
+	// PID scopes collection to /proc/[pid]/net/dev instead of the host-wide
+	// /proc/net/dev, e.g. to watch a single container's network namespace.
+	PID int
+
+	// Notifiers receive a Fire when a rate named in Thresholds crosses its
+	// Threshold on some interface for 3 consecutive samples, and a Resolve
+	// once it's back under threshold for 3 consecutive samples.
+	Notifiers []alerts.Notifier
+
+	// Thresholds configures an alerts.Policy, per interface, for each named
+	// rate field ("rx_bytes", "tx_bytes", "rx_packets", "tx_packets").
+	Thresholds []RateThreshold
+
+	// AlertInterval is the minimum time between two notifications for the
+	// same interface and field, Fire or Resolve alike: while a rate stays
+	// over threshold, Fire is re-sent at most once per AlertInterval, and a
+	// Resolve that comes within AlertInterval of the previous notification
+	// is not sent.
+	AlertInterval time.Duration
+}
+
+// RateThreshold configures an alert policy for one of this plugin's
+// per-interval rate fields, e.g. {Field: "rx_bytes", Threshold: 125e6} to
+// alert when an interface sustains >1Gbps of receive traffic.
+type RateThreshold struct {
+	Field     string
+	Threshold float64
+}
+
+// ratePolicy pairs the alerts.Policy for one interface/field pair with the
+// throttle state for re-notifying while it stays in the same state.
+type ratePolicy struct {
+	field      string
+	threshold  float64
+	policy     *alerts.Policy
+	lastUpdate time.Time
+}
+
+// Network samples /proc/net/dev (or /proc/[pid]/net/dev) on each tick and
+// emits both the raw cumulative counters and the per-interval rate derived
+// from them, which is the standard idiom for network metrics: downstream
+// systems can recompute a rate over an arbitrary window from the counter,
+// or read the pre-computed per-second value directly.
+type Network struct {
+	mux        sync.Mutex
+	pid        int
+	previous   map[string]counters
+	lastTime   time.Time
+	updaters   map[string]*updaters
+	registry   *metrics.Registry
+	labels     map[string]string
+	notifiers  []alerts.Notifier
+	thresholds []RateThreshold
+	policies   map[string]map[string]*ratePolicy // iface -> field -> policy
+	hostname   string
+	alertEvery time.Duration
+}
+
+// New starts a Network plugin sampling at cfg.SampleRate.
+func New(cfg *Config) *Network {
+	labels := map[string]string{"host": cfg.Hostname, "datacenter": cfg.Datacenter}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+
+	n := &Network{
+		pid:        cfg.PID,
+		previous:   make(map[string]counters),
+		updaters:   make(map[string]*updaters),
+		registry:   metrics.NewRegistry(cfg.Reporters, metrics.WithBaseLabels(labels)),
+		labels:     labels,
+		notifiers:  cfg.Notifiers,
+		thresholds: cfg.Thresholds,
+		policies:   make(map[string]map[string]*ratePolicy),
+		hostname:   cfg.Hostname,
+		alertEvery: cfg.AlertInterval,
+	}
+
+	n.registry.Start(context.Background(), cfg.ReportingInterval)
+
+	go func() {
+		n.collect()
+		for range time.Tick(cfg.SampleRate) {
+			n.collect()
+		}
+	}()
+
+	return n
+}
+
+func (n *Network) procPath() string {
+	if n.pid != 0 {
+		return fmt.Sprintf("/proc/%d/net/dev", n.pid)
+	}
+	return "/proc/net/dev"
+}
+
+func (n *Network) collect() {
+	defer func() {
+		if r := recover(); r != nil {
+			plugins.Logger.Printf("panic: network: %v\n", r)
+		}
+	}()
+
+	current, err := readDev(n.procPath())
+	if err != nil {
+		plugins.Logger.Printf("network: %v\n", err)
+		return
+	}
+
+	now := time.Now()
+
+	n.mux.Lock()
+
+	elapsed := now.Sub(n.lastTime).Seconds()
+	hasPrevious := !n.lastTime.IsZero()
+	n.lastTime = now
+
+	rates := make(map[string]map[string]float64, len(current))
+
+	for iface, c := range current {
+		u := n.updaterFor(iface)
+
+		prev := n.previous[iface]
+		var rxBytesRate, txBytesRate, rxPacketsRate, txPacketsRate float64
+		if hasPrevious && elapsed > 0 {
+			rxBytesRate = float64(delta(c.rxBytes, prev.rxBytes)) / elapsed
+			txBytesRate = float64(delta(c.txBytes, prev.txBytes)) / elapsed
+			rxPacketsRate = float64(delta(c.rxPackets, prev.rxPackets)) / elapsed
+			txPacketsRate = float64(delta(c.txPackets, prev.txPackets)) / elapsed
+		}
+
+		u.rxBytesTotal.Update(float64(c.rxBytes))
+		u.txBytesTotal.Update(float64(c.txBytes))
+		u.rxPacketsTotal.Update(float64(c.rxPackets))
+		u.txPacketsTotal.Update(float64(c.txPackets))
+		u.rxErrorsTotal.Update(float64(c.rxErrors))
+		u.txErrorsTotal.Update(float64(c.txErrors))
+		u.rxDropsTotal.Update(float64(c.rxDrops))
+		u.txDropsTotal.Update(float64(c.txDrops))
+		u.rxBytesRate.Update(rxBytesRate)
+		u.txBytesRate.Update(txBytesRate)
+		u.rxPacketsRate.Update(rxPacketsRate)
+		u.txPacketsRate.Update(txPacketsRate)
+
+		prefix := "net." + iface
+		labels := n.ifaceLabels(iface)
+		n.registry.ReportCounter(prefix+".rx_bytes_total", labels, int64(c.rxBytes))
+		n.registry.ReportCounter(prefix+".tx_bytes_total", labels, int64(c.txBytes))
+		n.registry.ReportCounter(prefix+".rx_packets_total", labels, int64(c.rxPackets))
+		n.registry.ReportCounter(prefix+".tx_packets_total", labels, int64(c.txPackets))
+		n.registry.ReportCounter(prefix+".rx_errors_total", labels, int64(c.rxErrors))
+		n.registry.ReportCounter(prefix+".tx_errors_total", labels, int64(c.txErrors))
+		n.registry.ReportCounter(prefix+".rx_drops_total", labels, int64(c.rxDrops))
+		n.registry.ReportCounter(prefix+".tx_drops_total", labels, int64(c.txDrops))
+		n.registry.ReportGauge(prefix+".rx_bytes", labels, rxBytesRate)
+		n.registry.ReportGauge(prefix+".tx_bytes", labels, txBytesRate)
+		n.registry.ReportGauge(prefix+".rx_packets", labels, rxPacketsRate)
+		n.registry.ReportGauge(prefix+".tx_packets", labels, txPacketsRate)
+
+		rates[iface] = map[string]float64{
+			"rx_bytes":   rxBytesRate,
+			"tx_bytes":   txBytesRate,
+			"rx_packets": rxPacketsRate,
+			"tx_packets": txPacketsRate,
+		}
+	}
+
+	n.previous = current
+
+	seen := make(map[string]bool, len(current))
+	for iface := range current {
+		seen[iface] = true
+	}
+	pruneUpdaters(n.updaters, n.policies, seen)
+
+	n.mux.Unlock()
+
+	// Thresholds are evaluated after releasing n.mux: Notifier.Fire/Resolve
+	// can block for seconds on a slow webhook or SMTP server, and holding
+	// the lock through that would stall Collect(b *metrics.Batch), which
+	// takes the same mutex, for every interface until the slowest notifier
+	// responds.
+	for iface, ifaceRates := range rates {
+		n.evaluateThresholds(iface, ifaceRates)
+	}
+}
+
+// evaluateThresholds runs every Thresholds entry against iface's rates for
+// this sample, notifying on Fire/Resolve the same way CPU does. Called from
+// collect after n.mux is released, since n.policies is only ever touched
+// from this single ticker goroutine.
+func (n *Network) evaluateThresholds(iface string, rates map[string]float64) {
+	if len(n.thresholds) == 0 {
+		return
+	}
+
+	policies, ok := n.policies[iface]
+	if !ok {
+		policies = make(map[string]*ratePolicy, len(n.thresholds))
+		for _, th := range n.thresholds {
+			policies[th.Field] = &ratePolicy{field: th.Field, threshold: th.Threshold, policy: alerts.NewPolicy(3, 3)}
+		}
+		n.policies[iface] = policies
+	}
+
+	for field, rp := range policies {
+		value, ok := rates[field]
+		if !ok {
+			continue
+		}
+
+		event := alerts.Event{
+			Host:      n.hostname,
+			Metric:    fmt.Sprintf("net.%s.%s", iface, field),
+			Value:     value,
+			Threshold: rp.threshold,
+			Message:   fmt.Sprintf("net.%s.%s %f is higher than %f", iface, field, value, rp.threshold),
+		}
+
+		fire, resolve := rp.policy.Evaluate(value >= rp.threshold)
+		if fire && time.Since(rp.lastUpdate) > n.alertEvery {
+			rp.lastUpdate = time.Now()
+			n.notify(event, true)
+		} else if resolve && time.Since(rp.lastUpdate) > n.alertEvery {
+			rp.lastUpdate = time.Now()
+			event.Message = fmt.Sprintf("net.%s.%s is within threshold", iface, field)
+			n.notify(event, false)
+		}
+	}
+}
+
+func (n *Network) notify(e alerts.Event, firing bool) {
+	ctx := context.Background()
+	for _, notifier := range n.notifiers {
+		var err error
+		if firing {
+			err = notifier.Fire(ctx, e)
+		} else {
+			err = notifier.Resolve(ctx, e)
+		}
+		if err != nil {
+			fmt.Printf("network: notify: %v\n", err)
+		}
+	}
+}
+
+// delta returns current-previous, clamped to 0 so a counter reset (an
+// interface bouncing) doesn't produce a negative rate.
+func delta(current, previous uint64) uint64 {
+	if current < previous {
+		return 0
+	}
+	return current - previous
+}
+
+// pruneUpdaters removes every updaters/policies entry whose interface is no
+// longer in seen, so an interface that disappears between samples (e.g. a
+// container veth torn down) doesn't leak entries for the life of the
+// monitor, the same way pruneMaxima does for the process plugin.
+func pruneUpdaters(updaters map[string]*updaters, policies map[string]map[string]*ratePolicy, seen map[string]bool) {
+	for iface := range updaters {
+		if !seen[iface] {
+			delete(updaters, iface)
+		}
+	}
+	for iface := range policies {
+		if !seen[iface] {
+			delete(policies, iface)
+		}
+	}
+}
+
+// ifaceLabels returns n.labels plus an "iface" label identifying iface, so
+// per-interface samples are distinguishable in the reporter registry
+// instead of only in the metric name.
+func (n *Network) ifaceLabels(iface string) map[string]string {
+	labels := make(map[string]string, len(n.labels)+1)
+	for k, v := range n.labels {
+		labels[k] = v
+	}
+	labels["iface"] = iface
+	return labels
+}
+
+func (n *Network) updaterFor(iface string) *updaters {
+	if u, ok := n.updaters[iface]; ok {
+		return u
+	}
+
+	ifaceLabels := n.ifaceLabels(iface)
+
+	prefix := "net." + iface
+	u := &updaters{
+		rxBytesTotal:   metrics.NewTaggedGauge(prefix+".rx_bytes_total", ifaceLabels),
+		txBytesTotal:   metrics.NewTaggedGauge(prefix+".tx_bytes_total", ifaceLabels),
+		rxPacketsTotal: metrics.NewTaggedGauge(prefix+".rx_packets_total", ifaceLabels),
+		txPacketsTotal: metrics.NewTaggedGauge(prefix+".tx_packets_total", ifaceLabels),
+		rxErrorsTotal:  metrics.NewTaggedGauge(prefix+".rx_errors_total", ifaceLabels),
+		txErrorsTotal:  metrics.NewTaggedGauge(prefix+".tx_errors_total", ifaceLabels),
+		rxDropsTotal:   metrics.NewTaggedGauge(prefix+".rx_drops_total", ifaceLabels),
+		txDropsTotal:   metrics.NewTaggedGauge(prefix+".tx_drops_total", ifaceLabels),
+		rxBytesRate:    metrics.NewTaggedGauge(prefix+".rx_bytes", ifaceLabels),
+		txBytesRate:    metrics.NewTaggedGauge(prefix+".tx_bytes", ifaceLabels),
+		rxPacketsRate:  metrics.NewTaggedGauge(prefix+".rx_packets", ifaceLabels),
+		txPacketsRate:  metrics.NewTaggedGauge(prefix+".tx_packets", ifaceLabels),
+	}
+	n.updaters[iface] = u
+	return u
+}
+
+// Collect fills b with the latest sample for every interface present in the
+// most recent read of /proc/net/dev, following the same Collect(*metrics.Batch) pattern used by CPU.
+func (n *Network) Collect(b *metrics.Batch) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	for _, u := range n.updaters {
+		for _, updater := range []metrics.Updater{
+			u.rxBytesTotal, u.txBytesTotal, u.rxPacketsTotal, u.txPacketsTotal,
+			u.rxErrorsTotal, u.txErrorsTotal, u.rxDropsTotal, u.txDropsTotal,
+			u.rxBytesRate, u.txBytesRate, u.rxPacketsRate, u.txPacketsRate,
+		} {
+			updater.Fill(b)
+		}
+	}
+}
+
+// readDev parses a /proc/net/dev-formatted file into per-interface counters.
+func readDev(path string) (map[string]counters, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("network: read %s: %w", path, err)
+	}
+
+	result := make(map[string]counters)
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, ":") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		iface := strings.TrimSpace(parts[0])
+		fields := strings.Fields(parts[1])
+		if iface == "" || len(fields) < 16 {
+			continue
+		}
+
+		result[iface] = counters{
+			rxBytes:   parseUint(fields[0]),
+			rxPackets: parseUint(fields[1]),
+			rxErrors:  parseUint(fields[2]),
+			rxDrops:   parseUint(fields[3]),
+			txBytes:   parseUint(fields[8]),
+			txPackets: parseUint(fields[9]),
+			txErrors:  parseUint(fields[10]),
+			txDrops:   parseUint(fields[11]),
+		}
+	}
+
+	return result, nil
+}
+
+func parseUint(s string) uint64 {
+	v, _ := strconv.ParseUint(s, 10, 64)
+	return v
+}
@@ -0,0 +1,42 @@
+package network
+
+import "testing"
+
+func TestPruneUpdatersRemovesVanishedInterfaces(t *testing.T) {
+	updaters := map[string]*updaters{
+		"eth0":     {},
+		"veth1234": {},
+	}
+	policies := map[string]map[string]*ratePolicy{
+		"eth0":     {},
+		"veth1234": {},
+	}
+	seen := map[string]bool{"eth0": true}
+
+	pruneUpdaters(updaters, policies, seen)
+
+	if _, ok := updaters["eth0"]; !ok {
+		t.Fatalf("pruneUpdaters removed a still-present interface's updaters")
+	}
+	if _, ok := updaters["veth1234"]; ok {
+		t.Fatalf("pruneUpdaters kept a vanished interface's updaters")
+	}
+	if _, ok := policies["eth0"]; !ok {
+		t.Fatalf("pruneUpdaters removed a still-present interface's policies")
+	}
+	if _, ok := policies["veth1234"]; ok {
+		t.Fatalf("pruneUpdaters kept a vanished interface's policies")
+	}
+}
+
+func TestPruneUpdatersKeepsEverythingWhenAllSeen(t *testing.T) {
+	updaters := map[string]*updaters{"eth0": {}}
+	policies := map[string]map[string]*ratePolicy{"eth0": {}}
+	seen := map[string]bool{"eth0": true}
+
+	pruneUpdaters(updaters, policies, seen)
+
+	if len(updaters) != 1 || len(policies) != 1 {
+		t.Fatalf("pruneUpdaters len = (%d, %d), want (1, 1)", len(updaters), len(policies))
+	}
+}
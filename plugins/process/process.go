@@ -0,0 +1,299 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/customerio/monitor/plugins"
+)
+
+// clockTicksPerSec is the kernel's USER_HZ, used to convert the utime/stime
+// fields in /proc/[pid]/stat (clock ticks) into seconds. It's 100 on every
+// mainstream Linux distribution; procfs doesn't expose the real value.
+const clockTicksPerSec = 100
+
+// ProcessKey identifies a single process instance. Keying by pid as well as
+// comm means a restarted process starts a fresh maxima entry rather than
+// silently continuing the old one's running maximum.
+type ProcessKey struct {
+	PID  int
+	Comm string
+}
+
+// Sample holds the resource usage observed for a process at a point in time.
+type Sample struct {
+	RSSBytes     uint64
+	VSZBytes     uint64
+	CPUSeconds   float64
+	IOReadBytes  uint64
+	IOWriteBytes uint64
+}
+
+func (s Sample) merge(o Sample) Sample {
+	if o.RSSBytes > s.RSSBytes {
+		s.RSSBytes = o.RSSBytes
+	}
+	if o.VSZBytes > s.VSZBytes {
+		s.VSZBytes = o.VSZBytes
+	}
+	if o.CPUSeconds > s.CPUSeconds {
+		s.CPUSeconds = o.CPUSeconds
+	}
+	if o.IOReadBytes > s.IOReadBytes {
+		s.IOReadBytes = o.IOReadBytes
+	}
+	if o.IOWriteBytes > s.IOWriteBytes {
+		s.IOWriteBytes = o.IOWriteBytes
+	}
+	return s
+}
+
+// Config configures the process plugin.
+type Config struct {
+	SampleRate time.Duration
+}
+
+// Reporter walks /proc on each sample tick and tracks the running maximum
+// of RSS, VSZ, CPU time, and IO bytes seen for every process, by pid+comm.
+type Reporter struct {
+	mux    sync.Mutex
+	maxima map[ProcessKey]Sample
+}
+
+// New starts a Reporter sampling /proc at cfg.SampleRate.
+func New(cfg *Config) *Reporter {
+	r := &Reporter{maxima: make(map[ProcessKey]Sample)}
+
+	go func() {
+		r.collect()
+		for range time.Tick(cfg.SampleRate) {
+			r.collect()
+		}
+	}()
+
+	return r
+}
+
+// GetMaxima returns a snapshot of the resource maxima observed so far for
+// every process that was still running at the last sample; entries for
+// processes that have exited are dropped by pruneMaxima.
+func (r *Reporter) GetMaxima() map[ProcessKey]Sample {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	snapshot := make(map[ProcessKey]Sample, len(r.maxima))
+	for k, v := range r.maxima {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+func (r *Reporter) collect() {
+	defer func() {
+		if rec := recover(); rec != nil {
+			plugins.Logger.Printf("panic: process: %v\n", rec)
+		}
+	}()
+
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		plugins.Logger.Printf("process: read /proc: %v\n", err)
+		return
+	}
+
+	seen := make(map[int]bool, len(entries))
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		seen[pid] = true
+
+		sample, comm, err := readProcess(pid)
+		if err != nil {
+			continue
+		}
+
+		key := ProcessKey{PID: pid, Comm: comm}
+
+		r.mux.Lock()
+		r.maxima[key] = r.maxima[key].merge(sample)
+		r.mux.Unlock()
+	}
+
+	r.mux.Lock()
+	pruneMaxima(r.maxima, seen)
+	r.mux.Unlock()
+}
+
+// pruneMaxima removes every key whose PID is no longer present in seen, so
+// the maxima map doesn't grow without bound over the life of a monitor that
+// watches a host with process churn (cron jobs, short-lived workers, ...).
+func pruneMaxima(maxima map[ProcessKey]Sample, seen map[int]bool) {
+	for key := range maxima {
+		if !seen[key.PID] {
+			delete(maxima, key)
+		}
+	}
+}
+
+func readProcess(pid int) (Sample, string, error) {
+	var sample Sample
+
+	comm, rss, vsz, err := readStatus(pid)
+	if err != nil {
+		return sample, "", err
+	}
+	sample.RSSBytes = rss
+	sample.VSZBytes = vsz
+
+	if cpu, err := readStat(pid); err == nil {
+		sample.CPUSeconds = cpu
+	}
+
+	if read, write, err := readIO(pid); err == nil {
+		sample.IOReadBytes = read
+		sample.IOWriteBytes = write
+	}
+
+	return sample, comm, nil
+}
+
+func readStatus(pid int) (comm string, rss, vsz uint64, err error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Name:"):
+			comm = strings.TrimSpace(strings.TrimPrefix(line, "Name:"))
+		case strings.HasPrefix(line, "VmRSS:"):
+			rss = parseKBField(line)
+		case strings.HasPrefix(line, "VmSize:"):
+			vsz = parseKBField(line)
+		}
+	}
+
+	return comm, rss, vsz, nil
+}
+
+func parseKBField(line string) uint64 {
+	kb := parseField(line)
+	return kb * 1024
+}
+
+func readStat(pid int) (float64, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	// comm can itself contain spaces and parens, so parse after its closing
+	// paren rather than naively splitting the whole line on spaces.
+	line := string(data)
+	idx := strings.LastIndex(line, ")")
+	if idx == -1 {
+		return 0, fmt.Errorf("process: malformed stat for pid %d", pid)
+	}
+
+	fields := strings.Fields(line[idx+1:])
+	// utime and stime are the 14th and 15th whitespace-separated fields
+	// overall, i.e. indexes 11 and 12 here, where pid and comm are removed
+	// and state is fields[0].
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("process: short stat for pid %d", pid)
+	}
+
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+
+	return float64(utime+stime) / clockTicksPerSec, nil
+}
+
+func readIO(pid int) (read, write uint64, err error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/io", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "read_bytes:"):
+			read = parseField(line)
+		case strings.HasPrefix(line, "write_bytes:"):
+			write = parseField(line)
+		}
+	}
+
+	return read, write, nil
+}
+
+func parseField(line string) uint64 {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return 0
+	}
+	v, _ := strconv.ParseUint(fields[1], 10, 64)
+	return v
+}
+
+// LogSummaryOnShutdown installs a SIGINT/SIGTERM handler that logs a
+// human-friendly peak-usage summary (e.g. "peak memory: nginx=412.0MB,
+// postgres=1.2GB") before the process exits, so operators have post-mortem
+// data even once the TSDB has downsampled the detail away.
+func (r *Reporter) LogSummaryOnShutdown() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-sig
+		r.logSummary()
+		os.Exit(0)
+	}()
+}
+
+func (r *Reporter) logSummary() {
+	peaks := make(map[string]uint64)
+	for key, sample := range r.GetMaxima() {
+		if sample.RSSBytes > peaks[key.Comm] {
+			peaks[key.Comm] = sample.RSSBytes
+		}
+	}
+
+	parts := make([]string, 0, len(peaks))
+	for comm, rss := range peaks {
+		parts = append(parts, fmt.Sprintf("%s=%s", comm, humanBytes(rss)))
+	}
+
+	plugins.Logger.Printf("peak memory: %s\n", strings.Join(parts, ", "))
+}
+
+func humanBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,45 @@
+package process
+
+import "testing"
+
+func TestPruneMaximaRemovesExitedPIDs(t *testing.T) {
+	maxima := map[ProcessKey]Sample{
+		{PID: 1, Comm: "nginx"}:    {RSSBytes: 100},
+		{PID: 2, Comm: "postgres"}: {RSSBytes: 200},
+	}
+	seen := map[int]bool{1: true}
+
+	pruneMaxima(maxima, seen)
+
+	if _, ok := maxima[ProcessKey{PID: 1, Comm: "nginx"}]; !ok {
+		t.Fatalf("pruneMaxima removed a still-running PID")
+	}
+	if _, ok := maxima[ProcessKey{PID: 2, Comm: "postgres"}]; ok {
+		t.Fatalf("pruneMaxima kept an exited PID")
+	}
+}
+
+func TestPruneMaximaKeepsEverythingWhenAllSeen(t *testing.T) {
+	maxima := map[ProcessKey]Sample{
+		{PID: 1, Comm: "nginx"}: {RSSBytes: 100},
+	}
+	seen := map[int]bool{1: true}
+
+	pruneMaxima(maxima, seen)
+
+	if len(maxima) != 1 {
+		t.Fatalf("pruneMaxima len = %d, want 1", len(maxima))
+	}
+}
+
+func TestSampleMergeKeepsRunningMaxima(t *testing.T) {
+	s := Sample{RSSBytes: 100, CPUSeconds: 5}
+	merged := s.merge(Sample{RSSBytes: 50, CPUSeconds: 10})
+
+	if merged.RSSBytes != 100 {
+		t.Fatalf("RSSBytes = %d, want 100 (should keep the larger value)", merged.RSSBytes)
+	}
+	if merged.CPUSeconds != 10 {
+		t.Fatalf("CPUSeconds = %v, want 10 (should keep the larger value)", merged.CPUSeconds)
+	}
+}
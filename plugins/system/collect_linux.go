@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/customerio/monitor/plugins"
 )
@@ -18,6 +19,7 @@ func pullFloat64(str string) float64 {
 }
 
 func (s *System) collect() {
+	start := time.Now()
 	defer func() {
 		if r := recover(); r != nil {
 			plugins.Logger.Printf("panic: System: %v\n", r)
@@ -33,6 +35,7 @@ func (s *System) collect() {
 
 	load_avg, _ := strconv.ParseFloat(strings.Split(string(data), " ")[0], 64)
 	s.updaters[loadAvgGauge].Update(load_avg)
+	s.setLast(loadAvgGauge, load_avg)
 
 	// Now some memory stats
 	meminfo, err := ioutil.ReadFile("/proc/meminfo")
@@ -61,15 +64,28 @@ func (s *System) collect() {
 		}
 	}
 
+	var mem_usage, swap_usage float64
+
 	if mem_total != 0.0 {
 		available := (mem_free + buffers + cached)
-		s.updaters[memUsageGauge].Update((mem_total - available) / mem_total * 100)
-	} else {
-		s.updaters[memUsageGauge].Update(0)
+		mem_usage = (mem_total - available) / mem_total * 100
 	}
+	s.updaters[memUsageGauge].Update(mem_usage)
+	s.setLast(memUsageGauge, mem_usage)
+
 	if swap_total != 0.0 {
-		s.updaters[swapUsageGauge].Update((swap_total - swap_free) / swap_total * 100)
-	} else {
-		s.updaters[swapUsageGauge].Update(0)
+		swap_usage = (swap_total - swap_free) / swap_total * 100
 	}
+	s.updaters[swapUsageGauge].Update(swap_usage)
+	s.setLast(swapUsageGauge, swap_usage)
+
+	s.registry.ReportGauge("load.avg", nil, load_avg)
+	s.registry.ReportGauge("mem.usage", nil, mem_usage)
+	s.registry.ReportGauge("swap.usage", nil, swap_usage)
+
+	// collectTimer is only updated here, on every SampleRate tick; it's
+	// snapshotted in Collect, which runs once per ReportingInterval, so the
+	// percentiles it reports actually aggregate many samples instead of
+	// resetting on every tick.
+	s.collectTimer.Update(time.Since(start))
 }
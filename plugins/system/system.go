@@ -0,0 +1,224 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/customerio/monitor/alerts"
+	"github.com/customerio/monitor/metrics"
+)
+
+const (
+	loadAvgGauge = iota
+	memUsageGauge
+	swapUsageGauge
+	gaugeCount
+)
+
+var gaugeNames = [...]string{
+	loadAvgGauge:   "load.avg",
+	memUsageGauge:  "mem.usage",
+	swapUsageGauge: "swap.usage",
+}
+
+func gaugeIndex(name string) (int, bool) {
+	for i, n := range gaugeNames {
+		if n == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+type Config struct {
+	SampleRate        time.Duration
+	ReportingInterval time.Duration
+	Hostname          string
+	Datacenter        string
+
+	// Labels are attached to every gauge emitted by this plugin in addition
+	// to host and datacenter.
+	Labels map[string]string
+
+	// Reporters receive every sample collected here in addition to the
+	// existing Batch sink, so operators can point this monitor at InfluxDB,
+	// Graphite, or any other metrics.Reporter without code changes.
+	Reporters []metrics.Reporter
+
+	// Notifiers receive a Fire when a gauge named in Thresholds crosses its
+	// Threshold for 3 consecutive samples, and a Resolve once it's back
+	// under threshold for 3 consecutive samples.
+	Notifiers []alerts.Notifier
+
+	// Thresholds configures an alerts.Policy for each named gauge ("load.avg",
+	// "mem.usage", "swap.usage"); gauges with no entry here are never
+	// alerted on.
+	Thresholds []GaugeThreshold
+
+	// AlertInterval is the minimum time between two notifications for the
+	// same gauge, Fire or Resolve alike: while a gauge stays over threshold,
+	// Fire is re-sent at most once per AlertInterval, and a Resolve that
+	// comes within AlertInterval of the previous notification is not sent.
+	AlertInterval time.Duration
+}
+
+// GaugeThreshold configures an alert policy for one of this plugin's
+// gauges, e.g. {Gauge: "mem.usage", Threshold: 90} for "mem.usage > 90 for
+// 3 samples".
+type GaugeThreshold struct {
+	Gauge     string
+	Threshold float64
+}
+
+// System samples /proc/loadavg and /proc/meminfo on each tick and reports
+// the 1-minute load average as read, plus memory usage and swap usage as
+// percentages.
+type System struct {
+	mux           sync.Mutex
+	last          []float64
+	updaters      []metrics.Updater
+	registry      *metrics.Registry
+	notifiers     []alerts.Notifier
+	collectTimer  *metrics.ResettingTimer
+	timerUpdaters map[float64]metrics.Updater
+}
+
+// gaugePolicy pairs the alerts.Policy for one gauge with the throttle state
+// for re-notifying while it stays in the same alert/resolved state.
+type gaugePolicy struct {
+	gauge      int
+	threshold  float64
+	policy     *alerts.Policy
+	lastUpdate time.Time
+}
+
+func baseLabels(hostname, datacenter string, extra map[string]string) map[string]string {
+	labels := map[string]string{"host": hostname, "datacenter": datacenter}
+	for k, v := range extra {
+		labels[k] = v
+	}
+	return labels
+}
+
+// New starts a System plugin sampling at cfg.SampleRate.
+func New(cfg *Config) *System {
+	labels := baseLabels(cfg.Hostname, cfg.Datacenter, cfg.Labels)
+
+	s := &System{
+		last:         make([]float64, gaugeCount),
+		updaters:     make([]metrics.Updater, gaugeCount),
+		registry:     metrics.NewRegistry(cfg.Reporters, metrics.WithBaseLabels(labels)),
+		notifiers:    cfg.Notifiers,
+		collectTimer: metrics.NewResettingTimer(0.5, 0.95, 0.99),
+		timerUpdaters: map[float64]metrics.Updater{
+			0.5:  metrics.NewTaggedGauge("monitor.collect.system.p50_ms", labels),
+			0.95: metrics.NewTaggedGauge("monitor.collect.system.p95_ms", labels),
+			0.99: metrics.NewTaggedGauge("monitor.collect.system.p99_ms", labels),
+		},
+	}
+
+	for _, g := range []int{loadAvgGauge, memUsageGauge, swapUsageGauge} {
+		s.updaters[g] = metrics.NewTaggedGauge(gaugeNames[g], labels)
+	}
+
+	var policies []*gaugePolicy
+	for _, th := range cfg.Thresholds {
+		gauge, ok := gaugeIndex(th.Gauge)
+		if !ok {
+			continue
+		}
+		policies = append(policies, &gaugePolicy{gauge: gauge, threshold: th.Threshold, policy: alerts.NewPolicy(3, 3)})
+	}
+
+	s.registry.Start(context.Background(), cfg.ReportingInterval)
+
+	go func() {
+		s.collect()
+		for range time.Tick(cfg.SampleRate) {
+			s.collect()
+			s.evaluateThresholds(cfg, policies)
+		}
+	}()
+
+	return s
+}
+
+// evaluateThresholds runs every configured gauge policy against the sample
+// that was just collected, notifying on Fire/Resolve the same way CPU does.
+func (s *System) evaluateThresholds(cfg *Config, policies []*gaugePolicy) {
+	for _, gp := range policies {
+		value := s.lastValue(gp.gauge)
+
+		event := alerts.Event{
+			Host:      cfg.Hostname,
+			Metric:    gaugeNames[gp.gauge],
+			Value:     value,
+			Threshold: gp.threshold,
+			Message:   fmt.Sprintf("%s %f is higher than %f", gaugeNames[gp.gauge], value, gp.threshold),
+		}
+
+		fire, resolve := gp.policy.Evaluate(value >= gp.threshold)
+		if fire && time.Since(gp.lastUpdate) > cfg.AlertInterval {
+			gp.lastUpdate = time.Now()
+			s.notify(event, true)
+		} else if resolve && time.Since(gp.lastUpdate) > cfg.AlertInterval {
+			gp.lastUpdate = time.Now()
+			event.Message = gaugeNames[gp.gauge] + " is within threshold"
+			s.notify(event, false)
+		}
+	}
+}
+
+func (s *System) notify(e alerts.Event, firing bool) {
+	ctx := context.Background()
+	for _, n := range s.notifiers {
+		var err error
+		if firing {
+			err = n.Fire(ctx, e)
+		} else {
+			err = n.Resolve(ctx, e)
+		}
+		if err != nil {
+			fmt.Printf("system: notify: %v\n", err)
+		}
+	}
+}
+
+// setLast records the latest sample for gauge so evaluateThresholds can read
+// it from the SampleRate goroutine without collect exposing its locals.
+func (s *System) setLast(gauge int, value float64) {
+	s.mux.Lock()
+	s.last[gauge] = value
+	s.mux.Unlock()
+}
+
+func (s *System) lastValue(gauge int) float64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.last[gauge]
+}
+
+// Collect fills b with the latest sample for every gauge.
+func (s *System) Collect(b *metrics.Batch) {
+	for _, u := range s.updaters {
+		u.Fill(b)
+	}
+
+	snapshot := s.collectTimer.Snapshot()
+	for _, p := range []float64{0.5, 0.95, 0.99} {
+		s.timerUpdaters[p].Update(float64(snapshot.Percentiles[p]) / float64(time.Millisecond))
+	}
+	for _, u := range s.timerUpdaters {
+		u.Fill(b)
+	}
+}
+
+// clear resets every gauge to 0 so a panic mid-collect doesn't leave a
+// stale high value reporting forever.
+func (s *System) clear() {
+	for _, u := range s.updaters {
+		u.Update(0)
+	}
+}